@@ -0,0 +1,276 @@
+package jks
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"os"
+	"time"
+)
+
+func dataSourceKeyStore() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads and parses an existing JKS key store. JCEKS stores are not supported.",
+		ReadContext: dataSourceStoreRead,
+		Schema:      storeContentSchema(),
+	}
+}
+
+func dataSourceTrustStore() *schema.Resource {
+	return &schema.Resource{
+		Description: "Reads and parses an existing JKS trust store. JCEKS stores are not supported.",
+		ReadContext: dataSourceStoreRead,
+		Schema:      storeContentSchema(),
+	}
+}
+
+// storeContentSchema is shared by jks_keystore and jks_truststore: both read
+// the same underlying format and expose the same entries.
+func storeContentSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"content_base64": {
+			Description:   "Base64 encoded store content. Conflicts with `filename`.",
+			Type:          schema.TypeString,
+			Optional:      true,
+			ConflictsWith: []string{"filename"},
+		},
+		"filename": {
+			Description:   "Path to the store file on disk. Conflicts with `content_base64`.",
+			Type:          schema.TypeString,
+			Optional:      true,
+			ConflictsWith: []string{"content_base64"},
+		},
+		"password": {
+			Description: "Password protecting the store. Defaults to empty string.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "",
+			Sensitive:   true,
+		},
+		"key_passwords": {
+			Description: "Per-alias passwords for private key entries that aren't protected by `password`.",
+			Type:        schema.TypeMap,
+			Optional:    true,
+			Sensitive:   true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"entries": {
+			Description: "Parsed contents of the store.",
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"alias": {
+						Description: "Alias the entry is stored under.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"type": {
+						Description: "Entry kind: `trusted_certificate` or `private_key`.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"creation_time": {
+						Description: "Entry creation time in RFC3339 format. Empty for a private_key entry whose password wasn't supplied.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"certificate_pem_chain": {
+						Description: "Certificate chain for this entry, leaf first; in PEM format. A single certificate for trusted_certificate entries.",
+						Type:        schema.TypeList,
+						Computed:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+					},
+					"subject": {
+						Description: "Subject of the leaf certificate.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"issuer": {
+						Description: "Issuer of the leaf certificate.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"not_before": {
+						Description: "Leaf certificate validity start, in RFC3339 format.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"not_after": {
+						Description: "Leaf certificate validity end, in RFC3339 format.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"serial": {
+						Description: "Leaf certificate serial number, in hex.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"sha1_fingerprint": {
+						Description: "SHA-1 fingerprint of the leaf certificate, hex encoded.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"sha256_fingerprint": {
+						Description: "SHA-256 fingerprint of the leaf certificate, hex encoded.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"private_key_pem": {
+						Description: "Decrypted private key in PKCS#8 PEM format; only set for private_key entries when the entry password was supplied.",
+						Type:        schema.TypeString,
+						Computed:    true,
+						Sensitive:   true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceStoreRead(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	var raw []byte
+
+	if content := d.Get("content_base64").(string); content != "" {
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return diag.Errorf("cant decode content_base64: %s", err.Error())
+		}
+		raw = decoded
+	} else if filename := d.Get("filename").(string); filename != "" {
+		file, err := os.ReadFile(filename)
+		if err != nil {
+			return diag.Errorf("cant read filename %q: %s", filename, err.Error())
+		}
+		raw = file
+	} else {
+		return diag.Errorf("one of content_base64 or filename is required")
+	}
+
+	password := d.Get("password").(string)
+
+	keyPasswordsRaw := d.Get("key_passwords").(map[string]interface{})
+	keyPasswords := map[string]string{}
+	for alias, p := range keyPasswordsRaw {
+		keyPasswords[alias] = p.(string)
+	}
+
+	ks := keystore.New(keystore.WithOrderedAliases())
+	if err := ks.Load(bytes.NewReader(raw), []byte(password)); err != nil {
+		return diag.Errorf("cant load store: %s", err.Error())
+	}
+
+	entries := []interface{}{}
+	for _, alias := range ks.Aliases() {
+		switch {
+		case ks.IsTrustedCertificateEntry(alias):
+			entry, err := ks.GetTrustedCertificateEntry(alias)
+			if err != nil {
+				return diag.Errorf("cant read trusted_certificate entry %q: %s", alias, err.Error())
+			}
+
+			parsed, err := x509.ParseCertificate(entry.Certificate.Content)
+			if err != nil {
+				return diag.Errorf("cant parse certificate for entry %q: %s", alias, err.Error())
+			}
+
+			entries = append(entries, flattenCertEntry(alias, "trusted_certificate", &entry.CreationTime, []*x509.Certificate{parsed}, ""))
+		case ks.IsPrivateKeyEntry(alias):
+			chainCerts, err := ks.GetPrivateKeyEntryCertificateChain(alias)
+			if err != nil {
+				return diag.Errorf("cant read certificate_chain for private_key entry %q: %s", alias, err.Error())
+			}
+
+			chain := make([]*x509.Certificate, len(chainCerts))
+			for i, cert := range chainCerts {
+				parsed, err := x509.ParseCertificate(cert.Content)
+				if err != nil {
+					return diag.Errorf("cant parse certificate_chain for entry %q: %s", alias, err.Error())
+				}
+				chain[i] = parsed
+			}
+
+			entryPassword := keyPasswords[alias]
+			if entryPassword == "" {
+				entryPassword = password
+			}
+
+			// Decrypting the entry requires its own password, which may not
+			// be supplied; the certificate chain above doesn't, so it's read
+			// unconditionally and only private_key_pem (and creation_time,
+			// which keystore-go only exposes alongside the decrypted key) are
+			// left empty when decryption fails.
+			var creationTime *time.Time
+			privateKeyPem := ""
+			if entry, err := ks.GetPrivateKeyEntry(alias, []byte(entryPassword)); err == nil {
+				creationTime = &entry.CreationTime
+				if key, err := x509.ParsePKCS8PrivateKey(entry.PrivateKey); err == nil {
+					if der, err := x509.MarshalPKCS8PrivateKey(key); err == nil {
+						privateKeyPem = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+					}
+				}
+			}
+
+			entries = append(entries, flattenCertEntry(alias, "private_key", creationTime, chain, privateKeyPem))
+		default:
+			return diag.Errorf("entry %q is neither a trusted_certificate nor a private_key entry", alias)
+		}
+	}
+
+	if err := d.Set("entries", entries); err != nil {
+		return diag.Errorf("failed to save entries: %v", err)
+	}
+
+	idHash := crypto.SHA1.New()
+	idHash.Write(raw)
+	d.SetId(hex.EncodeToString(idHash.Sum(nil)))
+
+	return nil
+}
+
+// flattenCertEntry builds the entries.* map for one alias out of its parsed
+// certificate chain; chain[0] is the leaf the subject/issuer/fingerprints
+// are reported for. creationTime is nil when it couldn't be determined
+// without decrypting a private_key entry's password-protected contents.
+func flattenCertEntry(alias, entryType string, creationTime *time.Time, chain []*x509.Certificate, privateKeyPem string) map[string]interface{} {
+	pemChain := make([]string, len(chain))
+	for i, cert := range chain {
+		pemChain[i] = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+
+	leaf := chain[0]
+
+	sha1Sum := crypto.SHA1.New()
+	sha1Sum.Write(leaf.Raw)
+
+	sha256Sum := crypto.SHA256.New()
+	sha256Sum.Write(leaf.Raw)
+
+	creationTimeStr := ""
+	if creationTime != nil {
+		creationTimeStr = creationTime.UTC().Format(time.RFC3339)
+	}
+
+	return map[string]interface{}{
+		"alias":                 alias,
+		"type":                  entryType,
+		"creation_time":         creationTimeStr,
+		"certificate_pem_chain": pemChain,
+		"subject":               leaf.Subject.String(),
+		"issuer":                leaf.Issuer.String(),
+		"not_before":            leaf.NotBefore.UTC().Format(time.RFC3339),
+		"not_after":             leaf.NotAfter.UTC().Format(time.RFC3339),
+		"serial":                fmt.Sprintf("%x", leaf.SerialNumber),
+		"sha1_fingerprint":      hex.EncodeToString(sha1Sum.Sum(nil)),
+		"sha256_fingerprint":    hex.EncodeToString(sha256Sum.Sum(nil)),
+		"private_key_pem":       privateKeyPem,
+	}
+}
@@ -0,0 +1,83 @@
+package jks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"sort"
+	"time"
+)
+
+// deterministicHKDFInfo distinguishes the salt stream this package derives
+// from any other HKDF use that might share the same seed.
+const deterministicHKDFInfo = "terraform-provider-jks deterministic store salt"
+
+// deterministicSchema returns the "deterministic" and "creation_time"
+// arguments shared by resources that can produce reproducible output.
+func deterministicSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"deterministic": {
+			Description: "Produce byte-identical output across runs by deriving the creation time and store salt from the inputs instead of the clock and system randomness.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			ForceNew:    true,
+		},
+		"creation_time": {
+			Description: "Explicit creation time to use in RFC3339 format when `deterministic` is true. Defaults to a time derived from the input certificates.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+		},
+	}
+}
+
+// deterministicCreationTime picks the CreationTime to stamp entries with
+// when deterministic mode is on: the explicit creation_time if given,
+// otherwise a time derived from the sorted hashes of certDers.
+func deterministicCreationTime(explicit string, certDers [][]byte) (time.Time, error) {
+	if explicit != "" {
+		return time.Parse(time.RFC3339, explicit)
+	}
+
+	seed := sha256.Sum256(sortedDerHash(certDers))
+
+	// Use the hash as an offset, in seconds, from the Unix epoch so the
+	// result is always a valid, stable timestamp.
+	var seconds int64
+	for _, b := range seed[:8] {
+		seconds = seconds<<8 | int64(b)
+	}
+	if seconds < 0 {
+		seconds = -seconds
+	}
+
+	return time.Unix(seconds%(50*365*24*3600), 0).UTC(), nil
+}
+
+// deterministicSaltReader returns an io.Reader whose output is fully
+// determined by password and certDers, so that encoding the same inputs
+// twice yields byte-identical store salts.
+func deterministicSaltReader(password string, certDers [][]byte) io.Reader {
+	return hkdf.New(sha256.New, []byte(password), sortedDerHash(certDers), []byte(deterministicHKDFInfo))
+}
+
+// sortedDerHash hashes each DER blob, sorts the resulting hex digests, and
+// concatenates them, so the salt is independent of input order.
+func sortedDerHash(certDers [][]byte) []byte {
+	digests := make([]string, len(certDers))
+	for i, der := range certDers {
+		sum := sha256.Sum256(der)
+		digests[i] = hex.EncodeToString(sum[:])
+	}
+	sort.Strings(digests)
+
+	joined := ""
+	for _, d := range digests {
+		joined += d
+	}
+
+	return []byte(joined)
+}
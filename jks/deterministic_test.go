@@ -0,0 +1,80 @@
+package jks
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDeterministicCreationTimeExplicit(t *testing.T) {
+	got, err := deterministicCreationTime("2024-01-02T03:04:05Z", nil)
+	if err != nil {
+		t.Fatalf("deterministicCreationTime: %v", err)
+	}
+
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("deterministicCreationTime() = %v, want %v", got, want)
+	}
+}
+
+func TestDeterministicCreationTimeInvalidExplicit(t *testing.T) {
+	if _, err := deterministicCreationTime("not-a-time", nil); err == nil {
+		t.Error("deterministicCreationTime(invalid) = nil error, want one")
+	}
+}
+
+func TestDeterministicCreationTimeDerived(t *testing.T) {
+	certDers := [][]byte{[]byte("cert-a"), []byte("cert-b")}
+
+	got1, err := deterministicCreationTime("", certDers)
+	if err != nil {
+		t.Fatalf("deterministicCreationTime: %v", err)
+	}
+
+	// Order of the input certs must not matter.
+	got2, err := deterministicCreationTime("", [][]byte{certDers[1], certDers[0]})
+	if err != nil {
+		t.Fatalf("deterministicCreationTime: %v", err)
+	}
+
+	if !got1.Equal(got2) {
+		t.Errorf("deterministicCreationTime() order dependent: %v != %v", got1, got2)
+	}
+
+	gotOther, err := deterministicCreationTime("", [][]byte{[]byte("cert-c")})
+	if err != nil {
+		t.Fatalf("deterministicCreationTime: %v", err)
+	}
+	if got1.Equal(gotOther) {
+		t.Errorf("deterministicCreationTime() produced the same time for different inputs")
+	}
+}
+
+func TestDeterministicSaltReader(t *testing.T) {
+	certDers := [][]byte{[]byte("cert-a"), []byte("cert-b")}
+
+	read := func(password string, certDers [][]byte) []byte {
+		buf := make([]byte, 32)
+		if _, err := io.ReadFull(deterministicSaltReader(password, certDers), buf); err != nil {
+			t.Fatalf("read salt: %v", err)
+		}
+		return buf
+	}
+
+	salt1 := read("pw", certDers)
+	salt2 := read("pw", [][]byte{certDers[1], certDers[0]}[:])
+
+	if !bytes.Equal(salt1, salt2) {
+		t.Errorf("deterministicSaltReader() order dependent: %x != %x", salt1, salt2)
+	}
+
+	if bytes.Equal(salt1, read("other-pw", certDers)) {
+		t.Errorf("deterministicSaltReader() produced the same salt for different passwords")
+	}
+
+	if bytes.Equal(salt1, read("pw", [][]byte{[]byte("cert-c")})) {
+		t.Errorf("deterministicSaltReader() produced the same salt for different certs")
+	}
+}
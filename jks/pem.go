@@ -0,0 +1,33 @@
+package jks
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+)
+
+// transformPemCertsToKeystoreCert decodes each PEM encoded certificate in
+// pemCerts and wraps its DER bytes as a keystore.Certificate, validating
+// along the way that each block actually parses as an X.509 certificate.
+func transformPemCertsToKeystoreCert(pemCerts []string) ([]keystore.Certificate, error) {
+	certs := make([]keystore.Certificate, 0, len(pemCerts))
+
+	for i, pemCert := range pemCerts {
+		block, _ := pem.Decode([]byte(pemCert))
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode PEM block for certificate %d", i)
+		}
+
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, fmt.Errorf("invalid certificate %d: %w", i, err)
+		}
+
+		certs = append(certs, keystore.Certificate{
+			Type:    "X.509",
+			Content: block.Bytes,
+		})
+	}
+
+	return certs, nil
+}
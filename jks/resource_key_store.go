@@ -0,0 +1,289 @@
+package jks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"github.com/youmark/pkcs8"
+	"time"
+)
+
+func resourceKeyStore() *schema.Resource {
+	s := map[string]*schema.Schema{
+		"entry": {
+			Description: "Private key entry to include in the generated key store.",
+			Type:        schema.TypeList,
+			Required:    true,
+			MinItems:    1,
+			ForceNew:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"alias": {
+						Description: "Alias under which the entry is stored.",
+						Type:        schema.TypeString,
+						Required:    true,
+						ForceNew:    true,
+					},
+					"private_key_pem": {
+						Description: "RSA, EC or Ed25519 private key in PEM format; may be PKCS#8 encrypted.",
+						Type:        schema.TypeString,
+						Required:    true,
+						Sensitive:   true,
+						ForceNew:    true,
+					},
+					"certificate_chain": {
+						Description: "Certificate chain for the private key, leaf first; in PEM format.",
+						Type:        schema.TypeList,
+						Required:    true,
+						MinItems:    1,
+						ForceNew:    true,
+						Elem: &schema.Schema{
+							Type: schema.TypeString,
+						},
+					},
+					"password": {
+						Description: "Password securing this entry. Defaults to the store password.",
+						Type:        schema.TypeString,
+						Optional:    true,
+						Sensitive:   true,
+						ForceNew:    true,
+					},
+					"sha1_fingerprint": {
+						Description: "SHA-1 fingerprint of the entry's leaf certificate, hex encoded.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+					"sha256_fingerprint": {
+						Description: "SHA-256 fingerprint of the entry's leaf certificate, hex encoded.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+				},
+			},
+		},
+		"password": {
+			Description: "Password to secure the key store. Defaults to empty string. With `pkcs12` format the file has a single password, which is the entry's password.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "",
+			Sensitive:   true,
+			ForceNew:    true,
+		},
+		"format": formatSchema(),
+		"timestamp": {
+			Description: "Timestamp of key store creation in RFC3339 format.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+	}
+	for attr, sch := range outputSchemas("Key store data; base64 encoded.") {
+		s[attr] = sch
+	}
+
+	return &schema.Resource{
+		Description:   "JKS key store generated from one or more private keys and their certificate chains.",
+		CreateContext: resourceKeyStoreCreate,
+		ReadContext:   resourceKeyStoreRead,
+		DeleteContext: resourceKeyStoreDelete,
+		Schema:        s,
+	}
+}
+
+func resourceKeyStoreCreate(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	ts, err := time.Parse(time.RFC3339, d.Get("timestamp").(string))
+	if err != nil {
+		ts = time.Now().Truncate(time.Second).UTC()
+		d.Set("timestamp", ts.Format(time.RFC3339))
+	}
+
+	storePassword := d.Get("password").(string)
+	format := storeFormat(d.Get("format").(string))
+
+	entries := d.Get("entry").([]interface{})
+	if len(entries) == 0 {
+		return diag.Errorf("empty entry")
+	}
+
+	contents := storeContents{}
+	flattenedEntries := make([]interface{}, len(entries))
+	var saltSeed [][]byte
+
+	for i, e := range entries {
+		entry := e.(map[string]interface{})
+
+		alias := entry["alias"].(string)
+
+		entryPassword := entry["password"].(string)
+		if entryPassword == "" {
+			entryPassword = storePassword
+		}
+
+		keyDer, key, err := transformPemKeyToPKCS8Der(entry["private_key_pem"].(string), entryPassword)
+		if err != nil {
+			return diag.Errorf("cant parse private key for entry %q: %s", alias, err.Error())
+		}
+
+		chainPemInterfaces := entry["certificate_chain"].([]interface{})
+		chainPems := []string{}
+		for _, ci := range chainPemInterfaces {
+			chainPems = append(chainPems, ci.(string))
+		}
+
+		chainCerts, err := transformPemCertsToKeystoreCert(chainPems)
+		if err != nil {
+			return diag.Errorf("cant transform pem certificate_chain for entry %q: %s", alias, err.Error())
+		}
+
+		leaf, err := x509.ParseCertificate(chainCerts[0].Content)
+		if err != nil {
+			return diag.Errorf("cant parse leaf certificate for entry %q: %s", alias, err.Error())
+		}
+
+		caChain := make([]*x509.Certificate, 0, len(chainCerts)-1)
+		for _, c := range chainCerts[1:] {
+			parsed, err := x509.ParseCertificate(c.Content)
+			if err != nil {
+				return diag.Errorf("cant parse certificate_chain entry for entry %q: %s", alias, err.Error())
+			}
+			caChain = append(caChain, parsed)
+		}
+
+		contents.PrivateKeys = append(contents.PrivateKeys, privateKeyEntryWithChain{
+			Alias: alias,
+			Entry: keystore.PrivateKeyEntry{
+				CreationTime:     ts,
+				PrivateKey:       keyDer,
+				CertificateChain: chainCerts,
+			},
+			Leaf:     leaf,
+			CAChain:  caChain,
+			Key:      key,
+			Password: []byte(entryPassword),
+		})
+
+		saltSeed = append(saltSeed, keyDer, chainCerts[0].Content)
+
+		sha1Sum := crypto.SHA1.New()
+		sha1Sum.Write(chainCerts[0].Content)
+
+		sha256Sum := crypto.SHA256.New()
+		sha256Sum.Write(chainCerts[0].Content)
+
+		entry["sha1_fingerprint"] = hex.EncodeToString(sha1Sum.Sum(nil))
+		entry["sha256_fingerprint"] = hex.EncodeToString(sha256Sum.Sum(nil))
+		flattenedEntries[i] = entry
+	}
+
+	if err := d.Set("entry", flattenedEntries); err != nil {
+		return diag.Errorf("failed to save entry: %v", err)
+	}
+
+	// The private keys and certificate chains are the resource's only inputs,
+	// so deriving the encoder's salt from them (rather than crypto/rand.Reader)
+	// keeps Create and the Read that immediately follows it byte-identical;
+	// otherwise the store, d.Id() and the jks/jceks/p12 attributes would all
+	// change on every refresh despite nothing having changed.
+	storeData, err := encodeStore(format, contents, []byte(storePassword), deterministicSaltReader(storePassword, saltSeed))
+	if err != nil {
+		return diag.Errorf("failed to generate %s key store: %s", format, err.Error())
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(storeData)
+
+	idHash := crypto.SHA1.New()
+	idHash.Write([]byte(encoded))
+
+	id := hex.EncodeToString(idHash.Sum([]byte{}))
+	d.SetId(id)
+
+	if err = setStoreOutputs(d, encoded); err != nil {
+		return diag.Errorf("%v", err)
+	}
+
+	return nil
+}
+
+func resourceKeyStoreRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return resourceKeyStoreCreate(ctx, d, m)
+}
+
+func resourceKeyStoreDelete(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	d.SetId("")
+
+	return diags
+}
+
+// transformPemKeyToPKCS8Der decodes a PEM encoded private key, transparently
+// handling encrypted PKCS#8 input, and re-encodes it as unencrypted PKCS#8
+// DER suitable for keystore.PrivateKeyEntry.PrivateKey. It also returns the
+// parsed key itself, which the PKCS#12 encoder needs.
+func transformPemKeyToPKCS8Der(keyPem string, password string) ([]byte, crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(keyPem))
+	if block == nil {
+		return nil, nil, fmt.Errorf("failed to decode PEM block for private key")
+	}
+
+	var key crypto.Signer
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse PKCS#1 private key: %w", err)
+		}
+		key = parsed
+	case "EC PRIVATE KEY":
+		parsed, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+		key = parsed
+	case "ENCRYPTED PRIVATE KEY":
+		parsed, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse encrypted PKCS#8 private key: %w", err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		key = signer
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse PKCS#8 private key: %w", err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		key = signer
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+	default:
+		return nil, nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return der, key, nil
+}
@@ -1,66 +1,72 @@
 package jks
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"crypto"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/pavel-v-chernykh/keystore-go/v4"
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"io"
 	"time"
 )
 
 func resourceTrustStore() *schema.Resource {
+	s := map[string]*schema.Schema{
+		"certificates": {
+			Description: "CA certificates or chains to include in generated trust store; in PEM format.",
+			Type:        schema.TypeList,
+			Required:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+			MinItems: 1,
+			ForceNew: true,
+		},
+		"password": {
+			Description: "Password to secure trust store. Defaults to empty string.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "",
+			ForceNew:    true,
+		},
+		"format": formatSchema(),
+		"timestamp": {
+			Description: "Timestamp of trust store creation in RFC3339 format.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+	}
+	for attr, sch := range outputSchemas("Trust store data; base64 encoded.") {
+		s[attr] = sch
+	}
+	for attr, sch := range tsaSchema() {
+		s[attr] = sch
+	}
+	s["verification"] = verificationSchema()
+	s["verification_report"] = &schema.Schema{
+		Description: "Per-certificate verification findings, as a JSON encoded string.",
+		Type:        schema.TypeString,
+		Computed:    true,
+	}
+	for attr, sch := range deterministicSchema() {
+		s[attr] = sch
+	}
+
 	return &schema.Resource{
 		Description:   "JKS trust store generated from one or more PEM encoded certificates.",
 		CreateContext: resourceTrustStoreCreate,
 		ReadContext:   resourceTrustStoreRead,
 		DeleteContext: resourceTrustStoreDelete,
-		Schema: map[string]*schema.Schema{
-			"certificates": {
-				Description: "CA certificates or chains to include in generated trust store; in PEM format.",
-				Type:        schema.TypeList,
-				Required:    true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
-				MinItems: 1,
-				ForceNew: true,
-			},
-			"password": {
-				Description: "Password to secure trust store. Defaults to empty string.",
-				Type:        schema.TypeString,
-				Optional:    true,
-				Default:     "",
-				ForceNew:    true,
-			},
-			"timestamp": {
-				Description: "Timestamp of trust store creation in RFC3339 format.",
-				Type:        schema.TypeString,
-				Computed:    true,
-			},
-			"jks": {
-				Description: "JKS trust store data; base64 encoded.",
-				Type:        schema.TypeString,
-				Computed:    true,
-			},
-		},
+		Schema:        s,
 	}
 }
 
 func resourceTrustStoreCreate(_ context.Context, d *schema.ResourceData, _ interface{}) diag.Diagnostics {
-	ks := keystore.New()
-
-	ts, err := time.Parse(time.RFC3339, d.Get("timestamp").(string))
-	if err != nil {
-		ts = time.Now().Truncate(time.Second).UTC()
-		d.Set("timestamp", ts.Format(time.RFC3339))
-	}
-
 	chainCertsInterfaces := d.Get("certificates").([]interface{})
 	if len(chainCertsInterfaces) == 0 {
 		return diag.Errorf("empty certificates")
@@ -74,42 +80,112 @@ func resourceTrustStoreCreate(_ context.Context, d *schema.ResourceData, _ inter
 	if err != nil {
 		return diag.Errorf("cant transform pem chainCerts to keystore chainCerts: %s", err.Error())
 	}
+
+	certDers := make([][]byte, len(keystoreCerts))
 	for i, keystoreCert := range keystoreCerts {
-		err := ks.SetTrustedCertificateEntry(
-			fmt.Sprintf("%d", i),
-			keystore.TrustedCertificateEntry{
-				CreationTime: ts,
-				Certificate:  keystoreCert,
-			},
-		)
+		certDers[i] = keystoreCert.Content
+	}
+
+	deterministic := d.Get("deterministic").(bool)
+	password := d.Get("password").(string)
+	format := storeFormat(d.Get("format").(string))
+
+	// go-pkcs12 always draws its PBE and MAC salts from the supplied
+	// io.Reader, so a pkcs12 store is unusable as Terraform state without
+	// deterministic = true: its id (and so the "reuse timestamp_token across
+	// refreshes" optimization below) would otherwise change on every refresh
+	// even though nothing about the inputs did.
+	if format == storeFormatPKCS12 && !deterministic {
+		return diag.Errorf("format %q requires deterministic = true: pkcs12 output is never byte-identical across refreshes otherwise", format)
+	}
+
+	var ts time.Time
+	if deterministic {
+		ts, err = deterministicCreationTime(d.Get("creation_time").(string), certDers)
+		if err != nil {
+			return diag.Errorf("cant parse creation_time: %s", err.Error())
+		}
+	} else {
+		ts, err = time.Parse(time.RFC3339, d.Get("timestamp").(string))
 		if err != nil {
-			return diag.Errorf("cant add cert %d to truststore: %s", err.Error())
+			ts = time.Now().Truncate(time.Second).UTC()
 		}
 	}
+	d.Set("timestamp", ts.Format(time.RFC3339))
+
+	if cfg := parseVerificationConfig(d); cfg != (verificationConfig{}) {
+		parsedCerts := make([]*x509.Certificate, len(keystoreCerts))
+		for i, keystoreCert := range keystoreCerts {
+			parsed, err := x509.ParseCertificate(keystoreCert.Content)
+			if err != nil {
+				return diag.Errorf("cant parse certificate %d for verification: %s", i, err.Error())
+			}
+			parsedCerts[i] = parsed
+		}
 
-	var jksBuffer bytes.Buffer
-	jksWriter := bufio.NewWriter(&jksBuffer)
+		report, err := verifyCertificates(parsedCerts, cfg)
+		if err := d.Set("verification_report", report); err != nil {
+			return diag.Errorf("failed to save verification_report: %v", err)
+		}
+		if err != nil {
+			return diag.Errorf("certificate verification failed: %s", err.Error())
+		}
+	}
 
-	err = ks.Store(jksWriter, []byte(d.Get("password").(string)))
-	if err != nil {
-		return diag.Errorf("failed to generate JKS: %s", err.Error())
+	contents := storeContents{}
+	for i, keystoreCert := range keystoreCerts {
+		contents.TrustedCerts = append(contents.TrustedCerts, trustedCertWithAlias{
+			Alias: fmt.Sprintf("%d", i),
+			Entry: keystore.TrustedCertificateEntry{
+				CreationTime: ts,
+				Certificate:  keystoreCert,
+			},
+		})
 	}
 
-	err = jksWriter.Flush()
+	var randReader io.Reader = rand.Reader
+	if deterministic {
+		randReader = deterministicSaltReader(password, certDers)
+	}
+
+	storeData, err := encodeStore(format, contents, []byte(password), randReader)
 	if err != nil {
-		return diag.Errorf("failed to flush JKS: %v", err)
+		return diag.Errorf("failed to generate %s trust store: %s", format, err.Error())
 	}
 
-	jksData := base64.StdEncoding.EncodeToString(jksBuffer.Bytes())
+	encoded := base64.StdEncoding.EncodeToString(storeData)
 
 	idHash := crypto.SHA1.New()
-	idHash.Write([]byte(jksData))
+	idHash.Write([]byte(encoded))
 
+	previousID := d.Id()
 	id := hex.EncodeToString(idHash.Sum([]byte{}))
 	d.SetId(id)
 
-	if err = d.Set("jks", jksData); err != nil {
-		return diag.Errorf("failed to save JKS: %v", err)
+	if err = setStoreOutputs(d, encoded); err != nil {
+		return diag.Errorf("%v", err)
+	}
+
+	if tsaURL := d.Get("tsa_server_url").(string); tsaURL != "" {
+		// The store content (and so its id) only changes when the inputs do,
+		// so an unchanged id means an unchanged store: reuse the existing
+		// token instead of hitting the TSA again on every refresh.
+		if id == previousID && d.Get("timestamp_token").(string) != "" {
+			return nil
+		}
+
+		token, tokenTime, err := attachTimestamp(storeData, tsaURL, d.Get("tsa_root_cert_pem").(string))
+		if err != nil {
+			return diag.Errorf("failed to timestamp trust store: %s", err.Error())
+		}
+
+		if err := d.Set("timestamp_token", token); err != nil {
+			return diag.Errorf("failed to save timestamp_token: %v", err)
+		}
+
+		if err := d.Set("timestamp_token_time", tokenTime); err != nil {
+			return diag.Errorf("failed to save timestamp_token_time: %v", err)
+		}
 	}
 
 	return nil
@@ -0,0 +1,123 @@
+package jks
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"io"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// trustedCertWithAlias pairs a keystore.TrustedCertificateEntry with the
+// alias it should be stored under.
+type trustedCertWithAlias struct {
+	Alias string
+	Entry keystore.TrustedCertificateEntry
+}
+
+// privateKeyEntryWithChain pairs a keystore.PrivateKeyEntry with the parsed
+// leaf x509.Certificate and key, which the PKCS#12 encoder needs but the JKS
+// encoder does not.
+type privateKeyEntryWithChain struct {
+	Alias    string
+	Entry    keystore.PrivateKeyEntry
+	Leaf     *x509.Certificate
+	CAChain  []*x509.Certificate
+	Key      interface{}
+	Password []byte
+}
+
+// storeContents is the neutral, format agnostic representation of the
+// entries that make up a trust store or key store. It is built once by the
+// resource's Create function and then handed to a storeEncoder.
+type storeContents struct {
+	TrustedCerts []trustedCertWithAlias
+	PrivateKeys  []privateKeyEntryWithChain
+}
+
+// storeEncoder produces the final on-disk bytes for a storeContents value.
+// randReader is the source of randomness for any salts the format needs;
+// callers pass crypto/rand.Reader unless they want deterministic output.
+type storeEncoder interface {
+	Encode(contents storeContents, password []byte, randReader io.Reader) ([]byte, error)
+}
+
+// encodeStore dispatches to the encoder registered for format.
+func encodeStore(format storeFormat, contents storeContents, password []byte, randReader io.Reader) ([]byte, error) {
+	var enc storeEncoder
+	switch format {
+	case storeFormatJKS:
+		enc = jksEncoder{}
+	case storeFormatJCEKS:
+		// pavlo-v-chernykh/keystore-go/v4 only ever writes the JKS magic and
+		// version (keystore.go:112-117); it has no JCEKS mode to select, so
+		// there is no way to honor this format without a separate encoder.
+		return nil, fmt.Errorf("jceks output format is not supported")
+	case storeFormatPKCS12:
+		enc = pkcs12Encoder{}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return enc.Encode(contents, password, randReader)
+}
+
+// jksEncoder writes JKS using pavlo-v-chernykh/keystore-go/v4.
+type jksEncoder struct{}
+
+func (e jksEncoder) Encode(contents storeContents, password []byte, randReader io.Reader) ([]byte, error) {
+	ks := keystore.New(keystore.WithOrderedAliases(), keystore.WithCustomRandomNumberGenerator(randReader))
+
+	for _, cert := range contents.TrustedCerts {
+		if err := ks.SetTrustedCertificateEntry(cert.Alias, cert.Entry); err != nil {
+			return nil, fmt.Errorf("cant add trusted cert %q: %w", cert.Alias, err)
+		}
+	}
+
+	for _, pk := range contents.PrivateKeys {
+		if err := ks.SetPrivateKeyEntry(pk.Alias, pk.Entry, pk.Password); err != nil {
+			return nil, fmt.Errorf("cant add private key entry %q: %w", pk.Alias, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+
+	if err := ks.Store(w, password); err != nil {
+		return nil, fmt.Errorf("failed to generate store: %w", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush store: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// pkcs12Encoder writes PKCS#12 using software.sslmate.com/src/go-pkcs12.
+type pkcs12Encoder struct{}
+
+func (e pkcs12Encoder) Encode(contents storeContents, password []byte, randReader io.Reader) ([]byte, error) {
+	if len(contents.PrivateKeys) == 0 {
+		certs := make([]*x509.Certificate, 0, len(contents.TrustedCerts))
+		for _, cert := range contents.TrustedCerts {
+			c, err := x509.ParseCertificate(cert.Entry.Certificate.Content)
+			if err != nil {
+				return nil, fmt.Errorf("cant parse trusted cert %q for pkcs12 encoding: %w", cert.Alias, err)
+			}
+			certs = append(certs, c)
+		}
+
+		return pkcs12.EncodeTrustStore(randReader, certs, string(password))
+	}
+
+	if len(contents.PrivateKeys) > 1 {
+		return nil, fmt.Errorf("pkcs12 format supports at most one private key entry, got %d", len(contents.PrivateKeys))
+	}
+
+	pk := contents.PrivateKeys[0]
+
+	return pkcs12.Encode(randReader, pk.Key, pk.Leaf, pk.CAChain, string(pk.Password))
+}
@@ -0,0 +1,68 @@
+package jks
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// storeFormat identifies which on-disk key/trust store encoding a resource
+// should produce.
+type storeFormat string
+
+const (
+	storeFormatJKS    storeFormat = "jks"
+	storeFormatJCEKS  storeFormat = "jceks"
+	storeFormatPKCS12 storeFormat = "pkcs12"
+)
+
+// storeFormatOutputAttribute maps a storeFormat to the computed attribute
+// name that carries its base64 encoded output.
+var storeFormatOutputAttribute = map[storeFormat]string{
+	storeFormatJKS:    "jks",
+	storeFormatJCEKS:  "jceks",
+	storeFormatPKCS12: "p12",
+}
+
+// formatSchema returns the shared "format" schema used by both
+// resourceTrustStore and resourceKeyStore.
+func formatSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Output store format: `jks`, `jceks` or `pkcs12`. Defaults to `jks`. `jceks` is accepted here, but generating the store then fails with an unsupported-format error.",
+		Type:        schema.TypeString,
+		Optional:    true,
+		Default:     string(storeFormatJKS),
+		ForceNew:    true,
+		ValidateFunc: validation.StringInSlice(
+			[]string{string(storeFormatJKS), string(storeFormatJCEKS), string(storeFormatPKCS12)},
+			false,
+		),
+	}
+}
+
+// outputSchemas returns the jks/jceks/p12 computed attributes shared by both
+// resources; they all alias the same base64 encoded store so existing
+// configurations keep working regardless of the chosen format.
+func outputSchemas(description string) map[string]*schema.Schema {
+	schemas := map[string]*schema.Schema{}
+	for _, attr := range storeFormatOutputAttribute {
+		schemas[attr] = &schema.Schema{
+			Description: description,
+			Type:        schema.TypeString,
+			Computed:    true,
+		}
+	}
+	return schemas
+}
+
+// setStoreOutputs stores the base64 encoded data under every one of the
+// jks/jceks/p12 attributes, so `jks`, `jceks` and `p12` all resolve to the
+// same blob regardless of which format was requested.
+func setStoreOutputs(d *schema.ResourceData, data string) error {
+	for _, attr := range storeFormatOutputAttribute {
+		if err := d.Set(attr, data); err != nil {
+			return fmt.Errorf("failed to save %s: %w", attr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package jks
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/digitorus/timestamp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"io"
+	"math/big"
+	"time"
+)
+
+// nonceLimit bounds the random nonce sent with a timestamp request; RFC3161
+// only requires it fit in an INTEGER, so any positive value works.
+var nonceLimit = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// tsaSchema returns the optional RFC3161 timestamping arguments and their
+// matching computed attributes, shared across resources that want to prove
+// when their output was generated.
+func tsaSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"tsa_server_url": {
+			Description: "RFC3161 timestamp authority URL to timestamp the generated store against.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+		},
+		"tsa_root_cert_pem": {
+			Description: "PEM encoded root certificate used to validate the timestamp authority's signer chain.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+		},
+		"timestamp_token": {
+			Description: "Raw RFC3161 TimeStampResp returned by the TSA; base64 encoded.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"timestamp_token_time": {
+			Description: "Timestamp embedded in the TSA's TSTInfo, in RFC3339 format.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+	}
+}
+
+// attachTimestamp requests an RFC3161 timestamp token for storeData from
+// tsaURL, verifies its message imprint and, when tsaRootPEM is set, its
+// signer chain, and returns the raw token (base64 encoded) plus the time
+// embedded in it.
+func attachTimestamp(storeData []byte, tsaURL string, tsaRootPEM string) (string, string, error) {
+	hash := sha256.Sum256(storeData)
+
+	nonce, err := rand.Int(rand.Reader, nonceLimit)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate timestamp request nonce: %w", err)
+	}
+
+	reqBytes, err := timestamp.CreateRequest(bytes.NewReader(storeData), &timestamp.RequestOptions{
+		Hash:         crypto.SHA256,
+		Certificates: true,
+		Nonce:        nonce,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build timestamp request: %w", err)
+	}
+
+	httpResp, err := revocationHTTPClient.Post(tsaURL, "application/timestamp-query", bytes.NewReader(reqBytes))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to reach TSA %q: %w", tsaURL, err)
+	}
+	defer httpResp.Body.Close()
+
+	tsrBytes, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read TSA response: %w", err)
+	}
+
+	token, err := timestamp.ParseResponse(tsrBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse TSA response: %w", err)
+	}
+
+	if !bytes.Equal(token.HashedMessage, hash[:]) {
+		return "", "", fmt.Errorf("TSA message imprint does not match store hash")
+	}
+
+	if token.Nonce == nil || token.Nonce.Cmp(nonce) != 0 {
+		return "", "", fmt.Errorf("TSA response nonce does not match request nonce")
+	}
+
+	if tsaRootPEM != "" {
+		if err := verifyTimestampSignerChain(token, tsaRootPEM); err != nil {
+			return "", "", fmt.Errorf("failed to verify TSA signer chain: %w", err)
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(tsrBytes), token.Time.UTC().Format(time.RFC3339), nil
+}
+
+// verifyTimestampSignerChain validates that the certificate which signed
+// token chains up to rootPEM.
+func verifyTimestampSignerChain(token *timestamp.Timestamp, rootPEM string) error {
+	block, _ := pem.Decode([]byte(rootPEM))
+	if block == nil {
+		return fmt.Errorf("failed to decode PEM block for tsa_root_cert_pem")
+	}
+
+	root, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse tsa_root_cert_pem: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range token.Certificates {
+		if cert.Equal(token.Certificates[0]) {
+			continue
+		}
+		intermediates.AddCert(cert)
+	}
+
+	if len(token.Certificates) == 0 {
+		return fmt.Errorf("TSA response did not include a signing certificate")
+	}
+
+	_, err = token.Certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return fmt.Errorf("signer certificate does not chain to root: %w", err)
+	}
+
+	return nil
+}
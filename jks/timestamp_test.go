@@ -0,0 +1,183 @@
+package jks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// newTestTSASigner issues a certificate under ca authorized for RFC3161
+// signing, as verifyTimestampSignerChain requires.
+func newTestTSASigner(t *testing.T, ca testCA, serial int64) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate TSA signer key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "tsa"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("create TSA signer cert: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse TSA signer cert: %v", err)
+	}
+
+	return cert, key
+}
+
+// fakeTSA serves RFC3161 responses signed by signerCert/signerKey, echoing
+// back the request's nonce unless corruptNonce is set. It returns the server
+// and a counter of how many requests it has handled.
+func fakeTSA(t *testing.T, signerCert *x509.Certificate, signerKey *ecdsa.PrivateKey, corruptNonce bool) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		reqBytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("read TSA request body: %v", err)
+		}
+
+		req, err := timestamp.ParseRequest(reqBytes)
+		if err != nil {
+			t.Fatalf("parse TSA request: %v", err)
+		}
+
+		nonce := req.Nonce
+		if corruptNonce && nonce != nil {
+			nonce = new(big.Int).Add(nonce, big.NewInt(1))
+		}
+
+		token := &timestamp.Timestamp{
+			HashAlgorithm:     req.HashAlgorithm,
+			HashedMessage:     req.HashedMessage,
+			Time:              time.Now().UTC(),
+			Nonce:             nonce,
+			Policy:            asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1},
+			AddTSACertificate: true,
+		}
+
+		respBytes, err := token.CreateResponseWithOpts(signerCert, signerKey, crypto.SHA256)
+		if err != nil {
+			t.Fatalf("create TSA response: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		_, _ = w.Write(respBytes)
+	}))
+
+	return server, &hits
+}
+
+func pemEncodeCert(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+func TestAttachTimestampRejectsNonceMismatch(t *testing.T) {
+	ca := newTestCA(t, "tsa-root")
+	signer, signerKey := newTestTSASigner(t, ca, 2)
+
+	server, _ := fakeTSA(t, signer, signerKey, true)
+	defer server.Close()
+
+	if _, _, err := attachTimestamp([]byte("store bytes"), server.URL, ""); err == nil {
+		t.Error("attachTimestamp() with a TSA that alters the nonce = nil error, want one")
+	}
+}
+
+func TestAttachTimestampVerifiesSignerChain(t *testing.T) {
+	ca := newTestCA(t, "tsa-root")
+	signer, signerKey := newTestTSASigner(t, ca, 2)
+
+	server, _ := fakeTSA(t, signer, signerKey, false)
+	defer server.Close()
+
+	token, tokenTime, err := attachTimestamp([]byte("store bytes"), server.URL, pemEncodeCert(ca.cert))
+	if err != nil {
+		t.Fatalf("attachTimestamp: %v", err)
+	}
+	if token == "" {
+		t.Error("attachTimestamp() returned an empty token")
+	}
+	if _, err := time.Parse(time.RFC3339, tokenTime); err != nil {
+		t.Errorf("attachTimestamp() returned an unparseable token time %q: %v", tokenTime, err)
+	}
+
+	otherCA := newTestCA(t, "other-root")
+	if _, _, err := attachTimestamp([]byte("store bytes"), server.URL, pemEncodeCert(otherCA.cert)); err == nil {
+		t.Error("attachTimestamp() with a root that didn't sign the TSA cert = nil error, want one")
+	}
+}
+
+// TestResourceTrustStoreReusesTimestampToken exercises the optimization added
+// alongside attachTimestamp: a refresh (resourceTrustStoreRead, which just
+// re-runs Create) must not re-hit the TSA when none of the trust store's
+// inputs changed.
+func TestResourceTrustStoreReusesTimestampToken(t *testing.T) {
+	tsaCA := newTestCA(t, "tsa-root")
+	signer, signerKey := newTestTSASigner(t, tsaCA, 2)
+
+	server, hits := fakeTSA(t, signer, signerKey, false)
+	defer server.Close()
+
+	trustedCA := newTestCA(t, "trusted-root")
+
+	raw := map[string]interface{}{
+		"certificates":   []interface{}{pemEncodeCert(trustedCA.cert)},
+		"tsa_server_url": server.URL,
+	}
+	d := schema.TestResourceDataRaw(t, resourceTrustStore().Schema, raw)
+
+	if diags := resourceTrustStoreCreate(context.Background(), d, nil); diags.HasError() {
+		t.Fatalf("create: %v", diags)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Fatalf("TSA hits after create = %d, want 1", got)
+	}
+
+	firstToken := d.Get("timestamp_token").(string)
+	if firstToken == "" {
+		t.Fatal("timestamp_token not set after create")
+	}
+
+	if diags := resourceTrustStoreRead(context.Background(), d, nil); diags.HasError() {
+		t.Fatalf("read: %v", diags)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Errorf("TSA hits after an unchanged refresh = %d, want 1 (token should have been reused)", got)
+	}
+	if got := d.Get("timestamp_token").(string); got != firstToken {
+		t.Errorf("timestamp_token changed across an unchanged refresh: %q != %q", got, firstToken)
+	}
+}
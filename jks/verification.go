@@ -0,0 +1,253 @@
+package jks
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/crypto/ocsp"
+	"io"
+	"net/http"
+	"time"
+)
+
+// revocationHTTPClient is used for all OCSP, CRL and TSA requests so a slow
+// or unresponsive server can't hang terraform apply indefinitely.
+var revocationHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// verificationConfig is the parsed form of the "verification" block.
+type verificationConfig struct {
+	CheckExpiry              bool
+	CheckOCSP                bool
+	CheckCRL                 bool
+	RequireCABasicConstraint bool
+	MinKeyBits               int
+}
+
+// verificationSchema returns the "verification" nested block shared by
+// resources that insert externally supplied certificates.
+func verificationSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Validation to run against input certificates before they are inserted.",
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		ForceNew:    true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"check_expiry": {
+					Description: "Reject certificates that are expired.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+				},
+				"check_ocsp": {
+					Description: "Reject certificates revoked according to their OCSP responder.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+				},
+				"check_crl": {
+					Description: "Reject certificates revoked according to their CRL distribution points.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+				},
+				"require_ca_basic_constraint": {
+					Description: "Reject certificates missing the CA basic constraint or the certSign key usage.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+				},
+				"min_key_bits": {
+					Description: "Reject certificates whose public key is smaller than this many bits. 0 disables the check.",
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Default:     0,
+				},
+			},
+		},
+	}
+}
+
+// parseVerificationConfig reads the "verification" block, if any, out of d.
+func parseVerificationConfig(d *schema.ResourceData) verificationConfig {
+	blocks := d.Get("verification").([]interface{})
+	if len(blocks) == 0 {
+		return verificationConfig{}
+	}
+
+	block := blocks[0].(map[string]interface{})
+
+	return verificationConfig{
+		CheckExpiry:              block["check_expiry"].(bool),
+		CheckOCSP:                block["check_ocsp"].(bool),
+		CheckCRL:                 block["check_crl"].(bool),
+		RequireCABasicConstraint: block["require_ca_basic_constraint"].(bool),
+		MinKeyBits:               block["min_key_bits"].(int),
+	}
+}
+
+// certFinding is one certificate's entry in the computed verification_report.
+type certFinding struct {
+	Subject string   `json:"subject"`
+	Issuer  string   `json:"issuer"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// verifyCertificates runs cfg's checks against certs. certs may be a single
+// chain (leaf first, root last) or a bundle of independent trust anchors, as
+// resourceTrustStore's "certificates" field allows either. It returns the
+// JSON encoded verification_report and, if any certificate failed a check,
+// a non-nil error describing every failure found.
+func verifyCertificates(certs []*x509.Certificate, cfg verificationConfig) (string, error) {
+	findings := make([]certFinding, len(certs))
+	var failures []string
+
+	for i, cert := range certs {
+		finding := certFinding{Subject: cert.Subject.String(), Issuer: cert.Issuer.String()}
+
+		if cfg.CheckExpiry && time.Now().After(cert.NotAfter) {
+			finding.Errors = append(finding.Errors, "certificate expired")
+		}
+
+		if cfg.RequireCABasicConstraint && (!cert.IsCA || cert.KeyUsage&x509.KeyUsageCertSign == 0) {
+			finding.Errors = append(finding.Errors, "missing CA basic constraint or keyCertSign usage")
+		}
+
+		if cfg.MinKeyBits > 0 {
+			if bits := publicKeyBits(cert); bits > 0 && bits < cfg.MinKeyBits {
+				finding.Errors = append(finding.Errors, fmt.Sprintf("public key is %d bits, want at least %d", bits, cfg.MinKeyBits))
+			}
+		}
+
+		if cfg.CheckOCSP && len(cert.OCSPServer) > 0 {
+			if issuer := findIssuer(certs, cert); issuer != nil {
+				revoked, err := checkOCSP(cert, issuer)
+				if err != nil {
+					finding.Errors = append(finding.Errors, fmt.Sprintf("OCSP check failed: %s", err.Error()))
+				} else if revoked {
+					finding.Errors = append(finding.Errors, "certificate revoked according to OCSP")
+				}
+			}
+		}
+
+		if cfg.CheckCRL {
+			revoked, err := checkCRL(cert)
+			if err != nil {
+				finding.Errors = append(finding.Errors, fmt.Sprintf("CRL check failed: %s", err.Error()))
+			} else if revoked {
+				finding.Errors = append(finding.Errors, "certificate revoked according to CRL")
+			}
+		}
+
+		for _, e := range finding.Errors {
+			failures = append(failures, fmt.Sprintf("%s: %s", finding.Subject, e))
+		}
+
+		findings[i] = finding
+	}
+
+	report, err := json.Marshal(findings)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode verification report: %w", err)
+	}
+
+	if len(failures) > 0 {
+		return string(report), fmt.Errorf("%d certificate(s) failed verification: %v", len(failures), failures)
+	}
+
+	return string(report), nil
+}
+
+func publicKeyBits(cert *x509.Certificate) int {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return pub.N.BitLen()
+	case *ecdsa.PublicKey:
+		return pub.Curve.Params().BitSize
+	default:
+		return 0
+	}
+}
+
+// findIssuer looks for the certificate in certs that issued cert, by
+// matching cert's Issuer against each candidate's Subject. It returns nil
+// for self-signed certificates (Subject == Issuer) and for certificates
+// whose issuer isn't present in certs, e.g. an independent trust anchor
+// bundled alongside unrelated roots rather than part of a chain.
+func findIssuer(certs []*x509.Certificate, cert *x509.Certificate) *x509.Certificate {
+	if cert.RawIssuer != nil && bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return nil
+	}
+
+	for _, candidate := range certs {
+		if candidate == cert {
+			continue
+		}
+		if bytes.Equal(candidate.RawSubject, cert.RawIssuer) {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+// checkOCSP queries issuer's OCSP responder for cert's revocation status.
+func checkOCSP(cert *x509.Certificate, issuer *x509.Certificate) (bool, error) {
+	reqBytes, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to build OCSP request: %w", err)
+	}
+
+	httpResp, err := revocationHTTPClient.Post(cert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(reqBytes))
+	if err != nil {
+		return false, fmt.Errorf("failed to reach OCSP responder %q: %w", cert.OCSPServer[0], err)
+	}
+	defer httpResp.Body.Close()
+
+	respBytes, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read OCSP response: %w", err)
+	}
+
+	ocspResp, err := ocsp.ParseResponseForCert(respBytes, cert, issuer)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse OCSP response: %w", err)
+	}
+
+	return ocspResp.Status == ocsp.Revoked, nil
+}
+
+// checkCRL fetches each of cert's CRL distribution points and reports
+// whether cert's serial number appears among the revoked entries.
+func checkCRL(cert *x509.Certificate) (bool, error) {
+	for _, url := range cert.CRLDistributionPoints {
+		httpResp, err := revocationHTTPClient.Get(url)
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch CRL %q: %w", url, err)
+		}
+
+		crlBytes, err := io.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
+		if err != nil {
+			return false, fmt.Errorf("failed to read CRL %q: %w", url, err)
+		}
+
+		revocationList, err := x509.ParseRevocationList(crlBytes)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse CRL %q: %w", url, err)
+		}
+
+		for _, entry := range revocationList.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
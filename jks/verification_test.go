@@ -0,0 +1,211 @@
+package jks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// testCA is a self-signed CA plus the key that signed it, used to build
+// leaf certificates for OCSP/CRL tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, commonName string) testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate CA key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create CA cert: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse CA cert: %v", err)
+	}
+
+	return testCA{cert: cert, key: key}
+}
+
+// newTestLeaf issues a leaf certificate signed by ca, with OCSPServer and
+// CRLDistributionPoints pointed at ocspURL/crlURL when non-empty.
+func newTestLeaf(t *testing.T, ca testCA, serial int64, ocspURL, crlURL string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate leaf key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if ocspURL != "" {
+		template.OCSPServer = []string{ocspURL}
+	}
+	if crlURL != "" {
+		template.CRLDistributionPoints = []string{crlURL}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("create leaf cert: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse leaf cert: %v", err)
+	}
+
+	return cert, key
+}
+
+func TestFindIssuer(t *testing.T) {
+	root := newTestCA(t, "root")
+	leaf, _ := newTestLeaf(t, root, 2, "", "")
+	unrelatedRoot := newTestCA(t, "unrelated")
+
+	certs := []*x509.Certificate{leaf, root.cert}
+
+	if got := findIssuer(certs, leaf); got != root.cert {
+		t.Errorf("findIssuer(leaf) = %v, want root cert", got)
+	}
+
+	if got := findIssuer(certs, root.cert); got != nil {
+		t.Errorf("findIssuer(self-signed root) = %v, want nil", got)
+	}
+
+	orphan, _ := newTestLeaf(t, unrelatedRoot, 3, "", "")
+	if got := findIssuer(certs, orphan); got != nil {
+		t.Errorf("findIssuer(orphan whose issuer is absent) = %v, want nil", got)
+	}
+}
+
+func TestCheckOCSP(t *testing.T) {
+	ca := newTestCA(t, "ca")
+
+	var respBytes []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(respBytes)
+	}))
+	defer server.Close()
+
+	leaf, _ := newTestLeaf(t, ca, 2, server.URL, "")
+
+	for _, tc := range []struct {
+		name    string
+		status  int
+		revoked bool
+	}{
+		{"good", ocsp.Good, false},
+		{"revoked", ocsp.Revoked, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			template := ocsp.Response{
+				Status:       tc.status,
+				SerialNumber: leaf.SerialNumber,
+				ThisUpdate:   time.Now().Add(-time.Minute),
+				NextUpdate:   time.Now().Add(time.Hour),
+			}
+			if tc.status == ocsp.Revoked {
+				template.RevokedAt = time.Now().Add(-time.Minute)
+			}
+
+			resp, err := ocsp.CreateResponse(ca.cert, ca.cert, template, ca.key)
+			if err != nil {
+				t.Fatalf("create OCSP response: %v", err)
+			}
+			respBytes = resp
+
+			revoked, err := checkOCSP(leaf, ca.cert)
+			if err != nil {
+				t.Fatalf("checkOCSP: %v", err)
+			}
+			if revoked != tc.revoked {
+				t.Errorf("checkOCSP() = %v, want %v", revoked, tc.revoked)
+			}
+		})
+	}
+}
+
+func TestCheckCRL(t *testing.T) {
+	ca := newTestCA(t, "ca")
+
+	var crlBytes []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(crlBytes)
+	}))
+	defer server.Close()
+
+	leaf, _ := newTestLeaf(t, ca, 2, "", server.URL)
+
+	emptyCRL, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatalf("create empty CRL: %v", err)
+	}
+	crlBytes = emptyCRL
+
+	revoked, err := checkCRL(leaf)
+	if err != nil {
+		t.Fatalf("checkCRL (not revoked): %v", err)
+	}
+	if revoked {
+		t.Errorf("checkCRL() = true, want false for a CRL that doesn't list the cert")
+	}
+
+	revokedCRL, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(2),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: leaf.SerialNumber, RevocationTime: time.Now().Add(-time.Minute)},
+		},
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatalf("create revoked CRL: %v", err)
+	}
+	crlBytes = revokedCRL
+
+	revoked, err = checkCRL(leaf)
+	if err != nil {
+		t.Fatalf("checkCRL (revoked): %v", err)
+	}
+	if !revoked {
+		t.Errorf("checkCRL() = false, want true for a CRL listing the cert's serial")
+	}
+}